@@ -0,0 +1,94 @@
+//===- asmcall.go - IR generation for inline assembly calls ---------------===//
+//
+//                     The LLVM Compiler Infrastructure
+//
+// This file is distributed under the University of Illinois Open Source
+// License. See LICENSE.TXT for details.
+//
+//===----------------------------------------------------------------------===//
+//
+// This file implements IR generation for GCC-style inline assembly, via a
+// `//go:asm` pragma that binds a Go function declaration to an asm template
+// plus constraint string. It lets runtime code write syscall wrappers and
+// other low-level hooks directly in Go, without a separate .s file.
+//
+// Building the actual inline-assembly value (llvm.InlineAsm or equivalent)
+// is backend-specific, so it is delegated to Builder.CreateInlineAsmCall
+// (see backend_llvm.go/backend_llir.go) rather than done here: this file
+// only knows about *govalue and types.Type.
+//
+//===----------------------------------------------------------------------===//
+
+package irgen
+
+import (
+	"llvm.org/llgo/third_party/go.tools/go/types"
+)
+
+// createAsmCall emits a call (or, when labels are given, a callbr) to an
+// inline assembly blob described by asm/constraints, following the same
+// argument and constraint conventions as llvm.InlineAsm: asm is the
+// assembler template, constraints is the GCC-style constraint string, and
+// labels are the blockaddress targets a callbr may jump to instead of
+// falling through, e.g. for a syscall that can return via a different path
+// on interrupt.
+//
+// resultType is the Go type of the (possibly tupled) result; it is mirrored,
+// in order, by the leading "=" output entries of constraints, which come
+// before the input entries. Results are returned as *govalue so callers can use them
+// exactly like an ordinary call's results.
+func (fr *frame) createAsmCall(asm, constraints string, argValues []*govalue, resultType types.Type, labels []BasicBlock) []*govalue {
+	args := make([]Value, len(argValues))
+	argTypes := make([]types.Type, len(argValues))
+	for i, arg := range argValues {
+		args[i] = arg.value
+		argTypes[i] = arg.Type()
+	}
+	resultTypes := flattenResultTypes(resultType)
+
+	var then BasicBlock
+	if len(labels) > 0 {
+		then = fr.module.AddBasicBlock(fr.function, "")
+	}
+	result := fr.builder.CreateInlineAsmCall(asm, constraints, args, argTypes, resultTypes, then, labels, "")
+	if len(labels) > 0 {
+		// callbr ends the current block; emission continues in the
+		// fall-through block, where its results are available.
+		fr.builder.SetInsertPoint(then)
+	}
+
+	return fr.unpackAsmResult(result, resultTypes)
+}
+
+// flattenResultTypes splits a (possibly tupled) Go result type into its
+// component types, the way typinfo already does for ordinary calls.
+func flattenResultTypes(t types.Type) []types.Type {
+	tuple, ok := t.(*types.Tuple)
+	if !ok {
+		if t == nil {
+			return nil
+		}
+		return []types.Type{t}
+	}
+	results := make([]types.Type, tuple.Len())
+	for i := range results {
+		results[i] = tuple.At(i).Type()
+	}
+	return results
+}
+
+// unpackAsmResult splits the (possibly struct-typed) result of an asm call
+// back into one *govalue per Go result type.
+func (fr *frame) unpackAsmResult(result Value, resultTypes []types.Type) []*govalue {
+	if len(resultTypes) == 0 {
+		return nil
+	}
+	if len(resultTypes) == 1 {
+		return []*govalue{newValue(result, resultTypes[0])}
+	}
+	values := make([]*govalue, len(resultTypes))
+	for i, t := range resultTypes {
+		values[i] = newValue(fr.builder.CreateExtractValue(result, i, ""), t)
+	}
+	return values
+}
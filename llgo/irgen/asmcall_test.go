@@ -0,0 +1,67 @@
+//===- asmcall_test.go - tests for inline assembly call support -----------===//
+//
+//                     The LLVM Compiler Infrastructure
+//
+// This file is distributed under the University of Illinois Open Source
+// License. See LICENSE.TXT for details.
+//
+//===----------------------------------------------------------------------===//
+
+package irgen
+
+import (
+	"testing"
+
+	"llvm.org/llgo/third_party/go.tools/go/types"
+)
+
+func TestFlattenResultTypes(t *testing.T) {
+	i := types.Typ[types.Int]
+	b := types.Typ[types.Bool]
+
+	tests := []struct {
+		name string
+		t    types.Type
+		want []types.Type
+	}{
+		{name: "nil result", t: nil, want: nil},
+		{name: "single result", t: i, want: []types.Type{i}},
+		{
+			name: "tupled results",
+			t: types.NewTuple(
+				types.NewVar(0, nil, "", i),
+				types.NewVar(0, nil, "", b),
+			),
+			want: []types.Type{i, b},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flattenResultTypes(tt.t)
+			if len(got) != len(tt.want) {
+				t.Fatalf("flattenResultTypes() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("flattenResultTypes()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterAsmFunc(t *testing.T) {
+	const name = "test.asmFuncUnderTest"
+	spec := AsmSpec{Asm: "syscall", Constraints: "={ax},{ax},{di},{si},{dx}"}
+
+	RegisterAsmFunc(name, spec)
+	defer delete(asmFuncs, name)
+
+	got, ok := asmFuncs[name]
+	if !ok {
+		t.Fatalf("asmFuncs[%q] not found after RegisterAsmFunc", name)
+	}
+	if got != spec {
+		t.Errorf("asmFuncs[%q] = %+v, want %+v", name, got, spec)
+	}
+}
@@ -0,0 +1,168 @@
+//===- backend.go - pluggable IR-emission backend -------------------------===//
+//
+//                     The LLVM Compiler Infrastructure
+//
+// This file is distributed under the University of Illinois Open Source
+// License. See LICENSE.TXT for details.
+//
+//===----------------------------------------------------------------------===//
+//
+// This file declares the interfaces that irgen uses to emit IR, so that the
+// code generator is not hard-wired to the CGO bindings in
+// llvm.org/llvm/bindings/go/llvm. A second, pure-Go implementation backed by
+// github.com/llir/llvm lives in backend_llir.go; it lets llgo be built and
+// tested without a local LLVM install.
+//
+//===----------------------------------------------------------------------===//
+
+package irgen
+
+import (
+	"llvm.org/llgo/third_party/go.tools/go/types"
+)
+
+// Context is the backend-specific context object threaded through type
+// lowering (e.g. an llvm.Context for the CGO backend); irgen only ever
+// passes it through, it never looks inside.
+type Context interface{}
+
+// Value is a single SSA value: an instruction, constant, function, global or
+// basic block argument.
+type Value interface {
+	// IsNil reports whether this Value wraps the zero value of the
+	// underlying backend representation.
+	IsNil() bool
+}
+
+// BasicBlock is a single basic block within a function.
+type BasicBlock interface {
+	IsNil() bool
+}
+
+// TailCallKind describes the tail-call marker, if any, that should be
+// attached to a call instruction.
+type TailCallKind int
+
+const (
+	// TailCallNone means the call is emitted with no tail-call marker.
+	TailCallNone TailCallKind = iota
+	// TailCallTail marks the call with LLVM's "tail" hint.
+	TailCallTail
+	// TailCallMustTail marks the call with LLVM's "musttail" marker.
+	TailCallMustTail
+)
+
+// Builder emits instructions into a basic block, following the cursor
+// conventions of llvm.Builder: CreateCall/CreateInvoke append at the
+// builder's current insertion point.
+type Builder interface {
+	// CreateCall emits a call instruction to fn with the given arguments.
+	CreateCall(fn Value, args []Value, name string) Value
+
+	// CreateInvoke emits an invoke instruction to fn, branching to then on
+	// normal return and to catch on unwind.
+	CreateInvoke(fn Value, args []Value, then, catch BasicBlock, name string) Value
+
+	// CreateCallBr emits a callbr instruction to fn, an inline assembly
+	// value built with indirect labels: it falls through to then like a
+	// plain call, but may instead branch to one of labels, which must
+	// correspond one-to-one with the "X" constraints passed to the asm.
+	CreateCallBr(fn Value, args []Value, then BasicBlock, labels []BasicBlock, name string) Value
+
+	// SetTailCall attaches a tail-call marker to a call instruction
+	// previously returned by CreateCall. Backends that cannot express the
+	// distinction are free to treat every kind as TailCallNone.
+	SetTailCall(call Value, kind TailCallKind)
+
+	// SetCallAttrs attaches the given call-site attributes to a call or
+	// invoke instruction previously returned by CreateCall/CreateInvoke.
+	SetCallAttrs(call Value, attrs CallAttrs)
+
+	// CreateExtractValue extracts field index out of an aggregate value,
+	// e.g. the struct result of a multi-result asm call.
+	CreateExtractValue(agg Value, index int, name string) Value
+
+	// CreatePHI builds a PHI node with one incoming edge per (value, block)
+	// pair in values/blocks, which must be parallel slices of equal length.
+	CreatePHI(values []Value, blocks []BasicBlock, name string) Value
+
+	// CreateLoad loads the value stored at ptr.
+	CreateLoad(ptr Value, name string) Value
+
+	// SetInsertPoint repositions the builder's cursor to the end of bb, so
+	// that subsequent Create* calls append there.
+	SetInsertPoint(bb BasicBlock)
+
+	// CreateInlineAsmCall builds an inline-assembly value from asm and
+	// constraints - GCC-style, following the conventions of
+	// llvm.InlineAsm - and emits a call to it, or a callbr branching to
+	// one of labels when labels is non-empty. argTypes and resultTypes are
+	// the Go types of the arguments and (possibly tupled) results, used to
+	// build the asm blob's function type; they play the same role fn's Go
+	// signature plays for CreateCall. A backend that cannot represent
+	// inline assembly should panic with a clear message rather than
+	// silently emit wrong code.
+	CreateInlineAsmCall(asm, constraints string, args []Value, argTypes, resultTypes []types.Type, then BasicBlock, labels []BasicBlock, name string) Value
+}
+
+// Module is the translation unit that functions and globals are emitted
+// into.
+type Module interface {
+	// AddBasicBlock appends a new, empty basic block to fn.
+	AddBasicBlock(fn Value, name string) BasicBlock
+
+	// String returns the module's textual IR representation.
+	String() string
+}
+
+// Backend is a factory for the Builder/Module pair used to emit a single
+// compilation unit. The default backend wraps the CGO LLVM bindings;
+// selecting the llir/llvm backend lets the rest of irgen run unmodified
+// against a pure-Go implementation.
+type Backend interface {
+	// Name identifies the backend, e.g. "llvm" or "llir".
+	Name() string
+
+	// NewModule creates the single Module a compilation unit is emitted
+	// into.
+	NewModule(name string) Module
+
+	// NewBuilder creates a Builder for use with the modules this backend
+	// produces. The returned Builder is not yet positioned in any basic
+	// block; callers must call SetInsertPoint before emitting anything.
+	NewBuilder() Builder
+}
+
+// backends holds the registered Backend factories, keyed by name. The
+// "llvm" backend (backend_llvm.go) is built only when cgo is enabled, so
+// this map may contain only "llir" in a cgo-free cross-compile.
+var backends = map[string]func() Backend{}
+
+// RegisterBackend makes a Backend factory available under name. It is
+// intended to be called from init in a file that implements a backend.
+func RegisterBackend(name string, new func() Backend) {
+	backends[name] = new
+}
+
+// NewBackend looks up a registered backend by name. An empty name means
+// "whatever llgo would have used before backends were pluggable": the
+// CGO-based "llvm" backend if it was compiled in, preserving existing
+// behaviour, or the sole remaining backend otherwise (e.g. "llir" in a
+// cgo-free build).
+func NewBackend(name string) (Backend, bool) {
+	if name == "" {
+		if _, ok := backends["llvm"]; ok {
+			name = "llvm"
+		} else {
+			for registered := range backends {
+				name = registered
+				break
+			}
+		}
+	}
+	new, ok := backends[name]
+	if !ok {
+		return nil, false
+	}
+	return new(), true
+}
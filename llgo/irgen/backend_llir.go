@@ -0,0 +1,185 @@
+//===- backend_llir.go - pure-Go llir/llvm-backed backend -----------------===//
+//
+//                     The LLVM Compiler Infrastructure
+//
+// This file is distributed under the University of Illinois Open Source
+// License. See LICENSE.TXT for details.
+//
+//===----------------------------------------------------------------------===//
+//
+// This file implements an irgen Backend on top of github.com/llir/llvm, a
+// pure-Go library for building and printing LLVM IR. Unlike the default
+// backend (backend_llvm.go) it requires no CGO and no local LLVM install, so
+// it lets llgo cross-compile itself and makes golden-file testing of irgen
+// output straightforward: Module.String() returns the textual .ll directly.
+//
+// Selecting it does not change anything else about irgen; callers that
+// request the default "" / "llvm" backend are unaffected.
+//
+//===----------------------------------------------------------------------===//
+
+package irgen
+
+import (
+	"llvm.org/llgo/third_party/go.tools/go/types"
+
+	"github.com/llir/llvm/ir"
+	irtypes "github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+func init() {
+	RegisterBackend("llir", func() Backend { return &llirBackend{module: ir.NewModule()} })
+}
+
+// llirValue adapts an llir/llvm value.Value to the Value interface.
+type llirValue struct{ value.Value }
+
+func (v llirValue) IsNil() bool { return v.Value == nil }
+
+// llirBasicBlock adapts an *ir.Block to the BasicBlock interface.
+type llirBasicBlock struct{ block *ir.Block }
+
+func (b llirBasicBlock) IsNil() bool { return b.block == nil }
+
+// llirBuilder emits instructions into the current *ir.Block. Unlike
+// llvm.Builder, llir/llvm instructions are appended directly to a block
+// rather than tracked by a separate cursor, so llirBuilder simply remembers
+// which block it is currently positioned in.
+type llirBuilder struct {
+	block *ir.Block
+}
+
+func (b *llirBuilder) CreateCall(fn Value, args []Value, name string) Value {
+	call := b.block.NewCall(toLLIRValue(fn), toLLIRValues(args)...)
+	call.LocalIdent.LocalName = name
+	return llirValue{call}
+}
+
+func (b *llirBuilder) CreateInvoke(fn Value, args []Value, then, catch BasicBlock, name string) Value {
+	inv := b.block.NewInvoke(toLLIRValue(fn), toLLIRValues(args),
+		then.(llirBasicBlock).block, catch.(llirBasicBlock).block)
+	inv.LocalIdent.LocalName = name
+	return llirValue{inv}
+}
+
+func (b *llirBuilder) CreateCallBr(fn Value, args []Value, then BasicBlock, labels []BasicBlock, name string) Value {
+	// github.com/llir/llvm has no callbr terminator yet. Runtime code that
+	// relies on the indirect-label edges (e.g. a syscall that can resume
+	// on interrupt via a different block) is not expressible here; emit a
+	// plain call and fall through to then, which is correct for every
+	// asm blob that never actually takes an indirect label.
+	call := b.CreateCall(fn, args, name)
+	b.block.NewBr(then.(llirBasicBlock).block)
+	return call
+}
+
+func (b *llirBuilder) SetTailCall(call Value, kind TailCallKind) {
+	inst := call.(llirValue).Value.(*ir.InstCall)
+	switch kind {
+	case TailCallMustTail:
+		inst.Tail = ir.TailMustTail
+	case TailCallTail:
+		inst.Tail = ir.TailTail
+	}
+}
+
+func (b *llirBuilder) SetCallAttrs(call Value, attrs CallAttrs) {
+	var funcAttrs []ir.FuncAttribute
+	if attrs.NoUnwind {
+		funcAttrs = append(funcAttrs, ir.FuncAttr("nounwind"))
+	}
+	if attrs.ReadNone {
+		funcAttrs = append(funcAttrs, ir.FuncAttr("readnone"))
+	} else if attrs.ReadOnly {
+		funcAttrs = append(funcAttrs, ir.FuncAttr("readonly"))
+	}
+	if attrs.ArgMemOnly {
+		funcAttrs = append(funcAttrs, ir.FuncAttr("argmemonly"))
+	}
+	if attrs.Cold {
+		funcAttrs = append(funcAttrs, ir.FuncAttr("cold"))
+	}
+	switch inst := call.(llirValue).Value.(type) {
+	case *ir.InstCall:
+		inst.FuncAttrs = append(inst.FuncAttrs, funcAttrs...)
+	case *ir.InstInvoke:
+		inst.FuncAttrs = append(inst.FuncAttrs, funcAttrs...)
+	}
+}
+
+func (b *llirBuilder) CreateExtractValue(agg Value, index int, name string) Value {
+	ev := b.block.NewExtractValue(toLLIRValue(agg), uint64(index))
+	ev.LocalIdent.LocalName = name
+	return llirValue{ev}
+}
+
+func (b *llirBuilder) CreatePHI(values []Value, blocks []BasicBlock, name string) Value {
+	incs := make([]*ir.Incoming, len(values))
+	for i, v := range values {
+		incs[i] = ir.NewIncoming(toLLIRValue(v), blocks[i].(llirBasicBlock).block)
+	}
+	phi := b.block.NewPhi(incs...)
+	phi.LocalIdent.LocalName = name
+	return llirValue{phi}
+}
+
+func (b *llirBuilder) CreateLoad(ptr Value, name string) Value {
+	elemType := toLLIRValue(ptr).Type().(*irtypes.PointerType).ElemType
+	load := b.block.NewLoad(elemType, toLLIRValue(ptr))
+	load.LocalIdent.LocalName = name
+	return llirValue{load}
+}
+
+func (b *llirBuilder) SetInsertPoint(bb BasicBlock) {
+	b.block = bb.(llirBasicBlock).block
+}
+
+func (b *llirBuilder) CreateInlineAsmCall(asm, constraints string, args []Value, argTypes, resultTypes []types.Type, then BasicBlock, labels []BasicBlock, name string) Value {
+	// github.com/llir/llvm has no representation for an inline-assembly
+	// callee (no llvm.InlineAsm equivalent), so //go:asm functions cannot
+	// be lowered to textual .ll through this backend yet. Fail loudly
+	// rather than silently dropping the asm and emitting a call to
+	// garbage, so callers notice at compile time instead of at runtime.
+	panic("irgen: //go:asm is not supported by the llir backend")
+}
+
+// llirModule adapts an *ir.Module to the Module interface.
+type llirModule struct{ module *ir.Module }
+
+func (m llirModule) AddBasicBlock(fn Value, name string) BasicBlock {
+	f := toLLIRValue(fn).(*ir.Func)
+	return llirBasicBlock{f.NewBlock(name)}
+}
+
+func (m llirModule) String() string {
+	return m.module.String()
+}
+
+// llirBackend is the Backend implementation for github.com/llir/llvm.
+type llirBackend struct {
+	module *ir.Module
+}
+
+func (*llirBackend) Name() string { return "llir" }
+
+func (b *llirBackend) NewModule(name string) Module {
+	b.module.SourceFilename = name
+	return llirModule{b.module}
+}
+
+func (*llirBackend) NewBuilder() Builder {
+	return &llirBuilder{}
+}
+
+func toLLIRValue(v Value) value.Value {
+	return v.(llirValue).Value
+}
+
+func toLLIRValues(vs []Value) []value.Value {
+	out := make([]value.Value, len(vs))
+	for i, v := range vs {
+		out[i] = toLLIRValue(v)
+	}
+	return out
+}
@@ -0,0 +1,214 @@
+//===- backend_llvm.go - CGO-bindings-backed backend ----------------------===//
+//
+//                     The LLVM Compiler Infrastructure
+//
+// This file is distributed under the University of Illinois Open Source
+// License. See LICENSE.TXT for details.
+//
+//===----------------------------------------------------------------------===//
+//
+// This file implements the default Backend, which wraps
+// llvm.org/llvm/bindings/go/llvm. It is the backend irgen used exclusively
+// before the Builder/Module/Value/BasicBlock interfaces were introduced, so
+// existing callers keep working unchanged.
+//
+//===----------------------------------------------------------------------===//
+
+//go:build cgo
+// +build cgo
+
+package irgen
+
+import (
+	"llvm.org/llgo/third_party/go.tools/go/types"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+func init() {
+	RegisterBackend("llvm", func() Backend { return llvmBackend{} })
+}
+
+// llvmValue adapts llvm.Value to the Value interface.
+type llvmValue struct{ llvm.Value }
+
+func (v llvmValue) IsNil() bool { return v.Value.IsNil() }
+
+// llvmBasicBlock adapts llvm.BasicBlock to the BasicBlock interface.
+type llvmBasicBlock struct{ llvm.BasicBlock }
+
+func (b llvmBasicBlock) IsNil() bool { return b.BasicBlock.IsNil() }
+
+// llvmBuilder adapts llvm.Builder to the Builder interface.
+type llvmBuilder struct{ llvm.Builder }
+
+func (b llvmBuilder) CreateCall(fn Value, args []Value, name string) Value {
+	return llvmValue{b.Builder.CreateCall(toLLVMValue(fn), toLLVMValues(args), name)}
+}
+
+func (b llvmBuilder) CreateInvoke(fn Value, args []Value, then, catch BasicBlock, name string) Value {
+	thenbb := then.(llvmBasicBlock).BasicBlock
+	catchbb := catch.(llvmBasicBlock).BasicBlock
+	return llvmValue{b.Builder.CreateInvoke(toLLVMValue(fn), toLLVMValues(args), thenbb, catchbb, name)}
+}
+
+func (b llvmBuilder) CreateCallBr(fn Value, args []Value, then BasicBlock, labels []BasicBlock, name string) Value {
+	thenbb := then.(llvmBasicBlock).BasicBlock
+	labelbbs := make([]llvm.BasicBlock, len(labels))
+	for i, l := range labels {
+		labelbbs[i] = l.(llvmBasicBlock).BasicBlock
+	}
+	return llvmValue{b.Builder.CreateCallBr(toLLVMValue(fn), toLLVMValues(args), thenbb, labelbbs, name)}
+}
+
+func (b llvmBuilder) SetTailCall(call Value, kind TailCallKind) {
+	v := toLLVMValue(call)
+	switch kind {
+	case TailCallMustTail, TailCallTail:
+		v.SetTailCallKind(toLLVMTailCallKind(kind))
+	}
+}
+
+func (b llvmBuilder) SetCallAttrs(call Value, attrs CallAttrs) {
+	v := toLLVMValue(call)
+	if attrs.NoUnwind {
+		v.AddCallSiteAttribute(llvm.AttributeIndex(-1), llvm.NoUnwindAttribute)
+	}
+	if attrs.ReadNone {
+		v.AddCallSiteAttribute(llvm.AttributeIndex(-1), llvm.ReadNoneAttribute)
+	} else if attrs.ReadOnly {
+		v.AddCallSiteAttribute(llvm.AttributeIndex(-1), llvm.ReadOnlyAttribute)
+	}
+	if attrs.ArgMemOnly {
+		v.AddCallSiteAttribute(llvm.AttributeIndex(-1), llvm.ArgMemOnlyAttribute)
+	}
+	if attrs.Cold {
+		v.AddCallSiteAttribute(llvm.AttributeIndex(-1), llvm.ColdAttribute)
+	}
+	for _, i := range attrs.NoCapture {
+		v.AddCallSiteAttribute(llvm.AttributeIndex(i+1), llvm.NoCaptureAttribute)
+	}
+	for _, i := range attrs.NoAlias {
+		v.AddCallSiteAttribute(llvm.AttributeIndex(i+1), llvm.NoAliasAttribute)
+	}
+}
+
+func (b llvmBuilder) CreateExtractValue(agg Value, index int, name string) Value {
+	return llvmValue{b.Builder.CreateExtractValue(toLLVMValue(agg), index, name)}
+}
+
+func (b llvmBuilder) CreatePHI(values []Value, blocks []BasicBlock, name string) Value {
+	phi := b.Builder.CreatePHI(toLLVMValue(values[0]).Type(), name)
+	llvmBlocks := make([]llvm.BasicBlock, len(blocks))
+	for i, bb := range blocks {
+		llvmBlocks[i] = bb.(llvmBasicBlock).BasicBlock
+	}
+	phi.AddIncoming(toLLVMValues(values), llvmBlocks)
+	return llvmValue{phi}
+}
+
+func (b llvmBuilder) CreateLoad(ptr Value, name string) Value {
+	return llvmValue{b.Builder.CreateLoad(toLLVMValue(ptr), name)}
+}
+
+func (b llvmBuilder) SetInsertPoint(bb BasicBlock) {
+	b.Builder.SetInsertPointAtEnd(bb.(llvmBasicBlock).BasicBlock)
+}
+
+func (b llvmBuilder) CreateInlineAsmCall(asm, constraints string, args []Value, argTypes, resultTypes []types.Type, then BasicBlock, labels []BasicBlock, name string) Value {
+	llvmArgTypes := make([]llvm.Type, len(argTypes))
+	for i, t := range argTypes {
+		llvmArgTypes[i] = asmLLVMType(t)
+	}
+	llvmResultTypes := make([]llvm.Type, len(resultTypes))
+	for i, t := range resultTypes {
+		llvmResultTypes[i] = asmLLVMType(t)
+	}
+	fnType := llvm.FunctionType(asmResultType(llvmResultTypes), llvmArgTypes, false)
+	fn := llvmValue{llvm.InlineAsm(fnType, asm, constraints, true, false, 0)}
+
+	if len(labels) == 0 {
+		return b.CreateCall(fn, args, name)
+	}
+	return b.CreateCallBr(fn, args, then, labels, name)
+}
+
+// asmLLVMType is a minimal Go-type-to-LLVM-type lowering for the scalar
+// and pointer types that appear in practice in //go:asm argument and
+// result lists; it does not need to handle the full generality of irgen's
+// type map.
+func asmLLVMType(t types.Type) llvm.Type {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch u.Kind() {
+		case types.Bool:
+			return llvm.Int1Type()
+		case types.Int8, types.Uint8:
+			return llvm.Int8Type()
+		case types.Int16, types.Uint16:
+			return llvm.Int16Type()
+		case types.Int32, types.Uint32:
+			return llvm.Int32Type()
+		case types.Int64, types.Uint64, types.Int, types.Uint, types.Uintptr:
+			return llvm.Int64Type()
+		}
+	case *types.Pointer:
+		return llvm.PointerType(asmLLVMType(u.Elem()), 0)
+	}
+	panic("irgen: //go:asm argument/result type not supported: " + t.String())
+}
+
+// asmResultType builds the single LLVM type - void, a scalar, or a struct -
+// that an inline asm blob with the given per-result LLVM types returns.
+func asmResultType(resultTypes []llvm.Type) llvm.Type {
+	switch len(resultTypes) {
+	case 0:
+		return llvm.VoidType()
+	case 1:
+		return resultTypes[0]
+	default:
+		return llvm.StructType(resultTypes, false)
+	}
+}
+
+// llvmModule adapts llvm.Module to the Module interface.
+type llvmModule struct{ llvm.Module }
+
+func (m llvmModule) AddBasicBlock(fn Value, name string) BasicBlock {
+	return llvmBasicBlock{llvm.AddBasicBlock(toLLVMValue(fn), name)}
+}
+
+func (m llvmModule) String() string {
+	return m.Module.String()
+}
+
+// llvmBackend is the Backend implementation for the CGO bindings.
+type llvmBackend struct{}
+
+func (llvmBackend) Name() string { return "llvm" }
+
+func (llvmBackend) NewModule(name string) Module {
+	return llvmModule{llvm.NewModule(name)}
+}
+
+func (llvmBackend) NewBuilder() Builder {
+	return llvmBuilder{llvm.GlobalContext().NewBuilder()}
+}
+
+func toLLVMValue(v Value) llvm.Value {
+	return v.(llvmValue).Value
+}
+
+func toLLVMValues(vs []Value) []llvm.Value {
+	out := make([]llvm.Value, len(vs))
+	for i, v := range vs {
+		out[i] = toLLVMValue(v)
+	}
+	return out
+}
+
+func toLLVMTailCallKind(kind TailCallKind) llvm.TailCallKind {
+	if kind == TailCallMustTail {
+		return llvm.MustTailCallKind
+	}
+	return llvm.TailCallKind
+}
@@ -14,26 +14,53 @@
 package irgen
 
 import (
+	"llvm.org/llgo/third_party/go.tools/go/ssa"
 	"llvm.org/llgo/third_party/go.tools/go/types"
-	"llvm.org/llvm/bindings/go/llvm"
 )
 
-// createCall emits the code for a function call,
-// taking into account receivers, and panic/defer.
-func (fr *frame) createCall(fn *govalue, argValues []*govalue) []*govalue {
+// createCall emits the code for a function call, taking into account
+// receivers, and panic/defer.
+//
+// IR emission goes through the Builder/Module abstraction rather than
+// directly against llvm.org/llvm/bindings/go/llvm, so the same irgen code
+// runs unchanged against either the CGO-backed backend (backend_llvm.go,
+// the default) or the pure-Go llir/llvm backend (backend_llir.go).
+//
+// instr is the ssa.Value of the call being lowered; it is used to look up a
+// `//go:asm` binding for the callee (see pragma.go), to derive call-site
+// attributes (see callattrs.go) and to decide whether this call sits in
+// tail position (see tailcall.go). Only an *ssa.Call gets any of these; instr
+// may be nil for calls irgen synthesizes itself.
+func (fr *frame) createCall(instr ssa.Value, fn *govalue, argValues []*govalue) []*govalue {
 	fntyp := fn.Type().Underlying().(*types.Signature)
+
+	if call, ok := instr.(*ssa.Call); ok {
+		if callee := call.Common().StaticCallee(); callee != nil {
+			if spec, ok := asmFuncs[callee.RelString(nil)]; ok {
+				return fr.createAsmCall(spec.Asm, spec.Constraints, argValues, fntyp.Results(), nil)
+			}
+		}
+	}
+
 	typinfo := fr.types.getSignatureInfo(fntyp)
 
-	args := make([]llvm.Value, len(argValues))
+	args := make([]Value, len(argValues))
 	for i, arg := range argValues {
 		args[i] = arg.value
 	}
-	var results []llvm.Value
+	attrs := callAttrs(instr)
+	var results []Value
 	if fr.unwindBlock.IsNil() {
-		results = typinfo.call(fr.types.ctx, fr.allocaBuilder, fr.builder, fn.value, args)
+		// A musttail/tail call cannot also unwind, so tail position is
+		// only considered on the non-invoke path.
+		tailKind := TailCallNone
+		if instr != nil {
+			tailKind = fr.tailCallKind(instr, fn)
+		}
+		results = typinfo.call(fr.types.ctx, fr.allocaBuilder, fr.builder, fn.value, args, tailKind, attrs)
 	} else {
-		contbb := llvm.AddBasicBlock(fr.function, "")
-		results = typinfo.invoke(fr.types.ctx, fr.allocaBuilder, fr.builder, fn.value, args, contbb, fr.unwindBlock)
+		contbb := fr.module.AddBasicBlock(fr.function, "")
+		results = typinfo.invoke(fr.types.ctx, fr.allocaBuilder, fr.builder, fn.value, args, contbb, fr.unwindBlock, attrs)
 	}
 
 	resultValues := make([]*govalue, len(results))
@@ -0,0 +1,114 @@
+//===- callattrs.go - call-site attributes ---------------------------------===//
+//
+//                     The LLVM Compiler Infrastructure
+//
+// This file is distributed under the University of Illinois Open Source
+// License. See LICENSE.TXT for details.
+//
+//===----------------------------------------------------------------------===//
+//
+// This file derives LLVM call-site attributes from information createCall
+// can get entirely from the ssa.Call it is lowering: a whitelist of known
+// no-panic runtime/builtin helpers, and whether every path from the call
+// ends in a panic, which are the two attributes irgen can back without a real
+// whole-program purity/escape analysis (which does not exist in this tree).
+// ReadNone/ReadOnly/ArgMemOnly/NoCapture/NoAlias remain on CallAttrs as
+// knobs a future analysis pass can populate; callAttrs does not set them.
+//
+//===----------------------------------------------------------------------===//
+
+package irgen
+
+import (
+	"llvm.org/llgo/third_party/go.tools/go/ssa"
+)
+
+// CallAttrs describes the LLVM call-site attributes and metadata that
+// createCall should attach to the call/invoke instruction it emits.
+// Builder implementations are free to ignore any attribute they have no
+// representation for.
+type CallAttrs struct {
+	// NoUnwind marks a call known never to panic.
+	NoUnwind bool
+	// ReadNone marks a call known to read no memory, Go or otherwise, and
+	// have no observable side effects (a pure function with no globals).
+	ReadNone bool
+	// ReadOnly marks a call known to read memory but not write it.
+	ReadOnly bool
+	// ArgMemOnly marks a call whose only memory accesses are through its
+	// pointer arguments.
+	ArgMemOnly bool
+	// NoCapture lists, by argument index, the pointer arguments the callee
+	// is known not to retain beyond the call.
+	NoCapture []int
+	// NoAlias lists, by argument index, the pointer arguments known not to
+	// alias any other argument or global visible to the callee.
+	NoAlias []int
+	// Cold marks a call as unlikely to execute, e.g. because every path
+	// from it ends in a panic; it hints the inliner.
+	Cold bool
+}
+
+// noPanicWhitelist names runtime and builtin helpers that are known not to
+// panic, and so can be marked "nounwind" even though irgen cannot otherwise
+// prove it from their Go signature alone.
+var noPanicWhitelist = map[string]bool{
+	"runtime.memset":     true,
+	"runtime.memcpy":     true,
+	"runtime.memequal":   true,
+	"runtime.typestring": true,
+}
+
+// callAttrs computes the CallAttrs for instr, the call currently being
+// lowered. Anything but an *ssa.Call, including nil for calls irgen
+// synthesizes itself, gets the zero CallAttrs.
+func callAttrs(instr ssa.Value) CallAttrs {
+	call, _ := instr.(*ssa.Call)
+	if call == nil {
+		return CallAttrs{}
+	}
+	var attrs CallAttrs
+	if callee := call.Common().StaticCallee(); callee != nil {
+		attrs.NoUnwind = noPanicWhitelist[callee.RelString(nil)]
+	}
+	attrs.Cold = isColdCall(call)
+	return attrs
+}
+
+// isColdCall reports whether every path from call's block ends in a block
+// that unconditionally panics - the pattern a Go error check like
+//
+//	if err != nil { panic(err) }
+//
+// lowers to. Such calls are unlikely to execute, so the inliner benefits
+// from knowing not to optimize for them. A path that loops back on itself
+// counts as not ending in a panic.
+func isColdCall(call *ssa.Call) bool {
+	return blockLeadsToPanic(call.Block(), make(map[*ssa.BasicBlock]bool))
+}
+
+// blockLeadsToPanic reports whether every path from block ends in a panic.
+// seen memoizes the answer per block; a block still being visited counts
+// as false.
+func blockLeadsToPanic(block *ssa.BasicBlock, seen map[*ssa.BasicBlock]bool) bool {
+	if result, ok := seen[block]; ok {
+		return result
+	}
+	seen[block] = false
+	for _, instr := range block.Instrs {
+		if _, ok := instr.(*ssa.Panic); ok {
+			seen[block] = true
+			return true
+		}
+	}
+	if len(block.Succs) == 0 {
+		return false
+	}
+	for _, succ := range block.Succs {
+		if !blockLeadsToPanic(succ, seen) {
+			return false
+		}
+	}
+	seen[block] = true
+	return true
+}
@@ -0,0 +1,112 @@
+//===- callattrs_test.go - tests for call-site attribute derivation -------===//
+//
+//                     The LLVM Compiler Infrastructure
+//
+// This file is distributed under the University of Illinois Open Source
+// License. See LICENSE.TXT for details.
+//
+//===----------------------------------------------------------------------===//
+
+package irgen
+
+import (
+	"testing"
+
+	"llvm.org/llgo/third_party/go.tools/go/ssa"
+)
+
+// lastCallInFunc returns the last *ssa.Call found in fn's blocks, in block
+// and instruction order.
+func lastCallInFunc(fn *ssa.Function) *ssa.Call {
+	var last *ssa.Call
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			if call, ok := instr.(*ssa.Call); ok {
+				last = call
+			}
+		}
+	}
+	return last
+}
+
+func TestCallAttrs(t *testing.T) {
+	tests := []struct {
+		name         string
+		src          string
+		wantNoUnwind bool
+		wantCold     bool
+	}{
+		{
+			name: "helper named like a whitelisted one outside runtime is not nounwind",
+			src: `package test
+				func f(dst []byte, v byte) {
+					memset(dst, v)
+				}
+				func memset(dst []byte, v byte)`,
+			wantNoUnwind: false,
+		},
+		{
+			name: "ordinary call is neither nounwind nor cold",
+			src: `package test
+				func g() {}
+				func f() { g() }`,
+		},
+		{
+			name: "call only reachable from a panic is cold",
+			src: `package test
+				func g(err error) {}
+				func f(err error) {
+					if err != nil {
+						g(err)
+						panic(err)
+					}
+				}`,
+			wantCold: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ssapkg := buildSSA(t, tt.src)
+			fn := ssapkg.Func("f")
+			if fn == nil {
+				t.Fatal("function f not found")
+			}
+			call := lastCallInFunc(fn)
+			if call == nil {
+				t.Fatal("no call found in f")
+			}
+			attrs := callAttrs(call)
+			if attrs.NoUnwind != tt.wantNoUnwind {
+				t.Errorf("NoUnwind = %v, want %v", attrs.NoUnwind, tt.wantNoUnwind)
+			}
+			if attrs.Cold != tt.wantCold {
+				t.Errorf("Cold = %v, want %v", attrs.Cold, tt.wantCold)
+			}
+		})
+	}
+}
+
+func TestCallAttrsWhitelist(t *testing.T) {
+	ssapkg := buildSSA(t, `package runtime
+		func memset(dst []byte, v byte)
+		func f(dst []byte, v byte) { memset(dst, v) }`)
+
+	fn := ssapkg.Func("f")
+	if fn == nil {
+		t.Fatal("function f not found")
+	}
+	call := lastCallInFunc(fn)
+	if call == nil {
+		t.Fatal("no call found in f")
+	}
+	if got := callAttrs(call).NoUnwind; !got {
+		t.Errorf("NoUnwind = %v, want true for a whitelisted runtime.memset call", got)
+	}
+}
+
+func TestCallAttrsNilInstr(t *testing.T) {
+	got := callAttrs(nil)
+	if got.NoUnwind || got.Cold || got.ReadNone || got.ReadOnly || got.ArgMemOnly || len(got.NoCapture) != 0 || len(got.NoAlias) != 0 {
+		t.Errorf("callAttrs(nil) = %+v, want the zero value", got)
+	}
+}
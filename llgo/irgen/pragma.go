@@ -0,0 +1,42 @@
+//===- pragma.go - //go:asm pragma registration ----------------------------===//
+//
+//                     The LLVM Compiler Infrastructure
+//
+// This file is distributed under the University of Illinois Open Source
+// License. See LICENSE.TXT for details.
+//
+//===----------------------------------------------------------------------===//
+//
+// This file is the binding point between a `//go:asm` pragma on a function
+// declaration and createCall's asm dispatch in call.go: a frontend pass that
+// scans doc comments for the pragma (not part of this tree) registers the
+// asm template and constraint string for the function it annotates by
+// calling RegisterAsmFunc from an init function; createCall then looks the
+// callee up by its fully-qualified name and, on a hit, emits an inline-asm
+// call instead of an ordinary one.
+//
+//===----------------------------------------------------------------------===//
+
+package irgen
+
+// AsmSpec is the GCC-style inline assembly bound to a Go function by a
+// `//go:asm` pragma: asm is the assembler template and constraints is the
+// constraint string, following the same conventions as llvm.InlineAsm and
+// Builder.CreateInlineAsmCall.
+type AsmSpec struct {
+	Asm         string
+	Constraints string
+}
+
+// asmFuncs maps a callee's fully-qualified name, as returned by
+// ssa.Function.RelString(nil), to the AsmSpec a `//go:asm` pragma bound to
+// it.
+var asmFuncs = map[string]AsmSpec{}
+
+// RegisterAsmFunc binds name, the fully-qualified name of a Go function
+// declaration (e.g. "runtime.syscall6"), to spec, so that a call to it is
+// lowered to inline assembly instead of an ordinary call. It is intended to
+// be called from init by whatever scans source for the `//go:asm` pragma.
+func RegisterAsmFunc(name string, spec AsmSpec) {
+	asmFuncs[name] = spec
+}
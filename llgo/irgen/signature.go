@@ -0,0 +1,68 @@
+//===- signature.go - per-signature call/invoke emission ------------------===//
+//
+//                     The LLVM Compiler Infrastructure
+//
+// This file is distributed under the University of Illinois Open Source
+// License. See LICENSE.TXT for details.
+//
+//===----------------------------------------------------------------------===//
+//
+// This file implements signatureInfo's call and invoke methods, the two
+// entry points createCall (call.go) uses to actually emit a call/invoke
+// instruction once it has decided on a tail-call kind and a set of
+// call-site attributes. Looked up once per *types.Signature via
+// llvmTypeMap.getSignatureInfo and cached there, a signatureInfo is reused
+// across every call site sharing that signature.
+//
+//===----------------------------------------------------------------------===//
+
+package irgen
+
+import (
+	"llvm.org/llgo/third_party/go.tools/go/types"
+)
+
+// signatureInfo caches how a *types.Signature is represented at the LLVM
+// level, so that call/invoke emission doesn't have to re-derive it at every
+// call site.
+type signatureInfo struct {
+	sig *types.Signature
+}
+
+// call emits a direct call to fn, then attaches tailKind and attrs to the
+// resulting instruction via builder, and splits its result into one Value
+// per Go result.
+func (si *signatureInfo) call(ctx Context, allocaBuilder, builder Builder, fn Value, args []Value, tailKind TailCallKind, attrs CallAttrs) []Value {
+	call := builder.CreateCall(fn, args, "")
+	builder.SetTailCall(call, tailKind)
+	builder.SetCallAttrs(call, attrs)
+	return si.unpackResults(builder, call)
+}
+
+// invoke emits an invoke to fn that branches to contbb on normal return and
+// unwindbb on unwind, then attaches attrs, and splits its result the same
+// way call does. Invokes are never tail calls: musttail/tail both require a
+// plain call instruction, since the caller's frame has to be reusable.
+func (si *signatureInfo) invoke(ctx Context, allocaBuilder, builder Builder, fn Value, args []Value, contbb, unwindbb BasicBlock, attrs CallAttrs) []Value {
+	call := builder.CreateInvoke(fn, args, contbb, unwindbb, "")
+	builder.SetCallAttrs(call, attrs)
+	return si.unpackResults(builder, call)
+}
+
+// unpackResults splits a call/invoke's (possibly struct-typed) result into
+// one Value per Go result, the same way asmcall.go does for an asm call.
+func (si *signatureInfo) unpackResults(builder Builder, call Value) []Value {
+	n := si.sig.Results().Len()
+	switch n {
+	case 0:
+		return nil
+	case 1:
+		return []Value{call}
+	default:
+		results := make([]Value, n)
+		for i := range results {
+			results[i] = builder.CreateExtractValue(call, i, "")
+		}
+		return results
+	}
+}
@@ -0,0 +1,138 @@
+//===- tailcall.go - tail-call analysis for call emission -----------------===//
+//
+//                     The LLVM Compiler Infrastructure
+//
+// This file is distributed under the University of Illinois Open Source
+// License. See LICENSE.TXT for details.
+//
+//===----------------------------------------------------------------------===//
+//
+// This file decides whether a call being emitted by createCall sits in tail
+// position, so that it can be marked "musttail" (or the weaker "tail") in
+// the emitted IR. This lets mutually recursive Go code - interpreters,
+// state machines - run without growing the stack.
+//
+//===----------------------------------------------------------------------===//
+
+package irgen
+
+import (
+	"llvm.org/llgo/third_party/go.tools/go/ssa"
+	"llvm.org/llgo/third_party/go.tools/go/types"
+)
+
+// tailCallKind determines the TailCallKind that should be attached to a call
+// to fn made while emitting instr, which must be the ssa.Value for the call
+// or invoke expression currently being lowered.
+//
+// instr is in tail position, and eligible for "musttail", when:
+//   - it is an ssa.Call (anything else gets no marker) followed in its
+//     block by an ssa.Return, with nothing in between but extractions of
+//     the call's own results,
+//   - that ssa.Return forwards exactly the call's results, unmodified,
+//   - fr has no pending deferred calls and no active unwind block (a
+//     musttail call cannot run cleanup code afterwards), and
+//   - the callee is not a receiver trampoline (a bound-method value or
+//     interface-method wrapper), which reshapes its receiver before
+//     calling the real method and so needs to unwind its own frame after
+//     that inner call returns - exactly what musttail forbids, and
+//   - the caller and callee signatures and calling conventions agree,
+//     since musttail requires the call to reuse the caller's frame.
+//
+// When all of these hold except the last, the weaker "tail" marker is used
+// instead: it permits, but does not require, the optimizer to reuse the
+// frame. When any other condition fails, the call gets no marker.
+func (fr *frame) tailCallKind(instr ssa.Value, fn *govalue) TailCallKind {
+	if len(fr.deferStack) > 0 || !fr.unwindBlock.IsNil() {
+		return TailCallNone
+	}
+	call, _ := instr.(*ssa.Call)
+	if call == nil {
+		return TailCallNone
+	}
+	block := call.Block()
+	ret := blockReturn(block, call)
+	if ret == nil {
+		return TailCallNone
+	}
+	if !returnsCallResultVerbatim(ret, call) {
+		return TailCallNone
+	}
+	if isTrampolineCallee(call) {
+		return TailCallNone
+	}
+	if sameSignatureAndCC(call.Parent().Signature, fn.Type()) {
+		return TailCallMustTail
+	}
+	return TailCallTail
+}
+
+// isTrampolineCallee reports whether call's statically-known callee is a
+// compiler-synthesized wrapper - a bound-method value, an interface-method
+// thunk, or similar - rather than a function with a real Go source body.
+// ssa.Function.Synthetic is non-empty exactly for such wrappers.
+func isTrampolineCallee(call *ssa.Call) bool {
+	callee := call.Common().StaticCallee()
+	return callee != nil && callee.Synthetic != ""
+}
+
+// sameSignatureAndCC reports whether caller and callee have identical
+// signatures and would therefore use the same calling convention, which
+// musttail requires in order to reuse the caller's frame.
+func sameSignatureAndCC(caller *types.Signature, callee types.Type) bool {
+	calleeSig, ok := callee.Underlying().(*types.Signature)
+	if !ok {
+		return false
+	}
+	return types.Identical(caller, calleeSig)
+}
+
+// blockReturn returns the ssa.Return that follows call within its block,
+// with nothing in between but ssa.Extracts of call's own results (as a
+// multi-result "return g()" lowers to), or nil if anything else, or
+// control flow, comes in between.
+func blockReturn(block *ssa.BasicBlock, call *ssa.Call) *ssa.Return {
+	instrs := block.Instrs
+	for i, instr := range instrs {
+		if instr == call {
+			for _, next := range instrs[i+1:] {
+				switch next := next.(type) {
+				case *ssa.Return:
+					return next
+				case *ssa.Extract:
+					if next.Tuple == call {
+						continue
+					}
+				}
+				return nil
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// returnsCallResultVerbatim reports whether ret returns exactly the results
+// of call, with no intervening conversion or extra work.
+func returnsCallResultVerbatim(ret *ssa.Return, call *ssa.Call) bool {
+	if len(ret.Results) == 0 {
+		// A bare "return" after a void call (func f() { g() }) forwards
+		// nothing because there is nothing to forward - that is as
+		// verbatim as it gets, so it qualifies just like any other case
+		// here, provided the call itself is void.
+		return call.Common().Signature().Results().Len() == 0
+	}
+	if len(ret.Results) == 1 && ret.Results[0] == call {
+		return true
+	}
+	if extract, ok := ret.Results[0].(*ssa.Extract); ok && extract.Tuple == call {
+		for i, res := range ret.Results {
+			extract, ok := res.(*ssa.Extract)
+			if !ok || extract.Tuple != call || extract.Index != i {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+//===- tailcall_test.go - tests for tail-position analysis ----------------===//
+//
+//                     The LLVM Compiler Infrastructure
+//
+// This file is distributed under the University of Illinois Open Source
+// License. See LICENSE.TXT for details.
+//
+//===----------------------------------------------------------------------===//
+
+package irgen
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"llvm.org/llgo/third_party/go.tools/go/ssa"
+	"llvm.org/llgo/third_party/go.tools/go/ssa/ssautil"
+	"llvm.org/llgo/third_party/go.tools/go/types"
+)
+
+// buildSSA builds the *ssa.Package for a single-file package with the
+// given source, with function bodies built so their ssa.Function.Blocks
+// are populated.
+func buildSSA(t *testing.T, src string) *ssa.Package {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "test.go", src, 0)
+	if err != nil {
+		t.Fatalf("parsing test source: %v", err)
+	}
+	pkg := types.NewPackage(f.Name.Name, f.Name.Name)
+	ssapkg, _, err := ssautil.BuildPackage(&types.Config{}, fset, pkg, []*ast.File{f}, ssa.SanityCheckFunctions)
+	if err != nil {
+		t.Fatalf("building ssa package: %v", err)
+	}
+	ssapkg.Build()
+	return ssapkg
+}
+
+// lastCall returns the last *ssa.Call in fn, in block and instruction
+// order, along with the ssa.Return that ends its block, if any.
+func lastCall(fn *ssa.Function) (*ssa.Call, *ssa.Return) {
+	call := lastCallInFunc(fn)
+	if call == nil {
+		return nil, nil
+	}
+	instrs := call.Block().Instrs
+	ret, _ := instrs[len(instrs)-1].(*ssa.Return)
+	return call, ret
+}
+
+func TestReturnsCallResultVerbatim(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want bool
+	}{
+		{
+			name: "void call, bare return",
+			src: `package test
+				func g() {}
+				func f() { g() }`,
+			want: true,
+		},
+		{
+			name: "single result forwarded directly",
+			src: `package test
+				func g() int { return 0 }
+				func f() int { return g() }`,
+			want: true,
+		},
+		{
+			name: "single result modified before return",
+			src: `package test
+				func g() int { return 0 }
+				func f() int { return g() + 1 }`,
+			want: false,
+		},
+		{
+			name: "multi-result forwarded verbatim",
+			src: `package test
+				func g() (int, int) { return 0, 0 }
+				func f() (int, int) { return g() }`,
+			want: true,
+		},
+		{
+			name: "multi-result reordered",
+			src: `package test
+				func g() (int, int) { return 0, 1 }
+				func f() (int, int) { a, b := g(); return b, a }`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ssapkg := buildSSA(t, tt.src)
+			fn := ssapkg.Func("f")
+			if fn == nil {
+				t.Fatal("function f not found")
+			}
+			call, ret := lastCall(fn)
+			if call == nil {
+				t.Fatal("no call found in f")
+			}
+			if ret == nil {
+				if tt.want {
+					t.Fatal("expected a trailing return, found none")
+				}
+				return
+			}
+			if got := returnsCallResultVerbatim(ret, call); got != tt.want {
+				t.Errorf("returnsCallResultVerbatim() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsTrampolineCallee(t *testing.T) {
+	ssapkg := buildSSA(t, `package test
+		type T struct{}
+		func (t T) M() int { return 0 }
+		func bound(t T) int {
+			m := t.M
+			return m()
+		}`)
+
+	bound := ssapkg.Func("bound")
+	if bound == nil {
+		t.Fatal("function bound not found")
+	}
+	var call *ssa.Call
+	for _, block := range bound.Blocks {
+		for _, instr := range block.Instrs {
+			if c, ok := instr.(*ssa.Call); ok {
+				call = c
+			}
+		}
+	}
+	if call == nil {
+		t.Fatal("no call found in bound")
+	}
+	if !isTrampolineCallee(call) {
+		t.Error("call to a bound-method wrapper should be a trampoline callee")
+	}
+}
+
+func TestSameSignatureAndCC(t *testing.T) {
+	ssapkg := buildSSA(t, `package test
+		func g(x int) int { return x }
+		func h(x int) (int, int) { return x, x }
+		func f(x int) int { return g(x) }`)
+
+	f := ssapkg.Func("f")
+	g := ssapkg.Func("g")
+	h := ssapkg.Func("h")
+	if f == nil || g == nil || h == nil {
+		t.Fatal("expected functions f, g, h")
+	}
+
+	if !sameSignatureAndCC(f.Signature, g.Type()) {
+		t.Error("f and g have identical signatures, want sameSignatureAndCC == true")
+	}
+	if sameSignatureAndCC(f.Signature, h.Type()) {
+		t.Error("f and h have different result counts, want sameSignatureAndCC == false")
+	}
+}
@@ -0,0 +1,37 @@
+//===- utils.go - misc irgen helpers ---------------------------------------===//
+//
+//                     The LLVM Compiler Infrastructure
+//
+// This file is distributed under the University of Illinois Open Source
+// License. See LICENSE.TXT for details.
+//
+//===----------------------------------------------------------------------===//
+//
+// This file holds small IR-emission helpers shared across frame methods,
+// routed through the Builder/BasicBlock abstraction rather than directly
+// against llvm.org/llvm/bindings/go/llvm.
+//
+//===----------------------------------------------------------------------===//
+
+package irgen
+
+import (
+	"llvm.org/llgo/third_party/go.tools/go/types"
+)
+
+// createPHI builds a PHI node with one incoming edge per (value, block)
+// pair, at builder's current insertion point.
+func (fr *frame) createPHI(builder Builder, values []*govalue, blocks []BasicBlock, name string) *govalue {
+	rawValues := make([]Value, len(values))
+	for i, v := range values {
+		rawValues[i] = v.value
+	}
+	phi := builder.CreatePHI(rawValues, blocks, name)
+	return newValue(phi, values[0].Type())
+}
+
+// createLoad loads the value stored at ptr, which must have Go type
+// *elemType.
+func (fr *frame) createLoad(builder Builder, ptr *govalue, elemType types.Type, name string) *govalue {
+	return newValue(builder.CreateLoad(ptr.value, name), elemType)
+}